@@ -2,37 +2,195 @@ package main
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/budgets"
 	"github.com/aws/aws-sdk-go/service/costexplorer"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	chart "github.com/wcharczuk/go-chart/v2"
 )
 
 type (
 	cost struct {
-		key    string
+		key                string
+		unit               string
+		monthToDate        float64
+		priorMonthToDate   float64
+		yesterday          float64
+		dayBeforeYesterday float64
+	}
+
+	costAmount struct {
+		amount float64
+		unit   string
+	}
+
+	forecast struct {
 		amount float64
 		unit   string
 	}
+
+	anomaly struct {
+		key            string
+		unit           string
+		amount         float64
+		baselineMean   float64
+		baselineStdDev float64
+		zScore         float64
+	}
+
+	// accountConfig is one entry of ACS_ACCOUNTS: an AWS account to assume
+	// a role into and fetch cost data from.
+	accountConfig struct {
+		ID      string `json:"id"`
+		RoleArn string `json:"roleArn"`
+		Alias   string `json:"alias"`
+	}
+
+	accountReport struct {
+		alias    string
+		costs    []cost
+		forecast forecast
+	}
+
+	// groupByConfig mirrors costexplorer.GroupDefinition for JSON config.
+	groupByConfig struct {
+		Type string `json:"type"`
+		Key  string `json:"key"`
+	}
+
+	// sectionConfig describes one additional report section read from
+	// ACS_CONFIG / ACS_CONFIG_FILE. Each section becomes its own Slack
+	// attachment alongside the default SERVICE/UnblendedCost breakdown.
+	sectionConfig struct {
+		Name        string                   `json:"name"`
+		Granularity string                   `json:"granularity"`
+		Metric      string                   `json:"metric"`
+		GroupBy     []groupByConfig          `json:"groupBy"`
+		Filter      *costexplorer.Expression `json:"filter"`
+	}
+
+	budgetAlert struct {
+		name       string
+		limit      float64
+		actual     float64
+		forecast   float64
+		unit       string
+		threshold  float64
+		percent    float64
+		forecasted bool
+		color      string
+	}
 )
 
 var (
 	webhook = os.Getenv("ACS_WEBHOOK")
 	channel = os.Getenv("ACS_CHANNEL")
+
+	accountID    = os.Getenv("ACS_AWS_ACCOUNT_ID")
+	alertMention = os.Getenv("ACS_BUDGET_ALERT_MENTION")
+
+	slackToken = os.Getenv("ACS_SLACK_TOKEN")
+
+	httpAddr           = os.Getenv("ACS_HTTP_ADDR")
+	slackSigningSecret = os.Getenv("ACS_SLACK_SIGNING_SECRET")
+)
+
+// chartServiceCount is how many of the top services are plotted in the bar
+// chart; the rest are folded into the text report only.
+const chartServiceCount = 10
+
+// anomalyBaselineDays is how many trailing days of DAILY cost data are used
+// to compute each service's baseline mean/stddev.
+const anomalyBaselineDays = 30
+
+// anomalyMinSamples is the minimum number of baseline days required before
+// a service is eligible for anomaly detection, to avoid flagging brand new
+// services off a couple of data points.
+const anomalyMinSamples = 7
+
+// accountWorkerPoolSize bounds how many accounts are fetched concurrently
+// when ACS_ACCOUNTS is set, to avoid overwhelming Cost Explorer/STS.
+const accountWorkerPoolSize = 5
+
+var (
+	// anomalySigma is how many standard deviations above the baseline mean
+	// yesterday's spend must exceed to be flagged as an anomaly.
+	anomalySigma = parseEnvFloat("ACS_ANOMALY_SIGMA", 3)
+
+	// anomalyMinUSD suppresses anomalies on line items whose spend never
+	// reaches this amount, to avoid noise on tiny services.
+	anomalyMinUSD = parseEnvFloat("ACS_ANOMALY_MIN_USD", 0)
+
+	// anomalyMaxJumpPercent additionally flags a service whose relative
+	// jump over its baseline mean exceeds this percentage, even if the
+	// sigma threshold isn't crossed. 0 disables this check.
+	anomalyMaxJumpPercent = parseEnvFloat("ACS_ANOMALY_MAX_JUMP_PERCENT", 0)
 )
 
+func parseEnvFloat(name string, def float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.WithField("value", raw).Warnf("failed to parse %s, using default", name)
+		return def
+	}
+
+	return v
+}
+
+// budgetThresholds are the spend percentages (of a budget's limit) that
+// trigger a Slack alert. Defaults to 50/80/100% when ACS_BUDGET_THRESHOLDS
+// is not set.
+var budgetThresholds = parseThresholds(os.Getenv("ACS_BUDGET_THRESHOLDS"))
+
+func parseThresholds(raw string) []float64 {
+	if raw == "" {
+		return []float64{50, 80, 100}
+	}
+
+	thresholds := make([]float64, 0)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			log.WithField("value", part).Warn("failed to parse budget threshold, ignoring")
+			continue
+		}
+		thresholds = append(thresholds, v)
+	}
+	sort.Float64s(thresholds)
+
+	return thresholds
+}
+
 func init() {
 	log.SetFormatter(&log.JSONFormatter{})
 }
@@ -41,6 +199,10 @@ func main() {
 	switch os.Getenv("ENV") {
 	case "local":
 		start()
+	case "server":
+		if err := startServer(); err != nil {
+			log.Fatal(err)
+		}
 	default:
 		lambda.Start(func() error {
 			return run(webhook, channel)
@@ -55,42 +217,464 @@ func start() {
 }
 
 func run(webhook string, channel string) error {
-	details, err := getCosts()
+	sess, err := session.NewSession()
+	if err != nil {
+		return errors.Wrap(err, "failed to create new AWS session")
+	}
+	costExplorer := costexplorer.New(sess)
+
+	details, monthForecast, err := getCosts(costExplorer)
 	if err != nil {
 		return errors.Wrap(err, "failed to get cost")
 	}
 
-	if err := postSlack(webhook, channel, details); err != nil {
+	alerts, err := getBudgetAlerts(sess, details)
+	if err != nil {
+		return errors.Wrap(err, "failed to get budget alerts")
+	}
+
+	sections, err := loadSectionConfigs()
+	if err != nil {
+		return errors.Wrap(err, "failed to load section config")
+	}
+
+	sectionCosts, err := getSectionCosts(costExplorer, sections)
+	if err != nil {
+		return errors.Wrap(err, "failed to get section cost")
+	}
+
+	anomalies, err := getAnomalies(costExplorer, details)
+	if err != nil {
+		return errors.Wrap(err, "failed to get cost anomalies")
+	}
+
+	accountReports, err := getAccountCosts()
+	if err != nil {
+		return errors.Wrap(err, "failed to get per-account cost")
+	}
+
+	// Posting the chart is best-effort and additive: ACS_SLACK_TOKEN is only
+	// needed for files.upload, so any failure here falls back to the plain
+	// webhook text report below rather than aborting the run.
+	if slackToken != "" {
+		if err := postSlackChart(costExplorer, slackToken, channel, details); err != nil {
+			log.WithError(err).Warn("failed to post cost chart to slack, falling back to text report")
+		}
+	}
+
+	if err := postSlack(webhook, channel, details, monthForecast, alerts, sectionCosts, anomalies, accountReports); err != nil {
 		return errors.Wrap(err, "failed to send cost into slack")
 	}
 
 	return nil
 }
 
-func getCosts() ([]cost, error) {
-	session, err := session.NewSession()
+// loadAccountConfigs reads the AWS Organizations member accounts to report
+// on from ACS_ACCOUNTS (a JSON array). Returns nil when unset, leaving the
+// tool in its original single-account mode.
+func loadAccountConfigs() ([]accountConfig, error) {
+	raw := os.Getenv("ACS_ACCOUNTS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	accounts := make([]accountConfig, 0)
+	if err := json.Unmarshal([]byte(raw), &accounts); err != nil {
+		return nil, errors.Wrap(err, "failed to parse ACS_ACCOUNTS")
+	}
+
+	return accounts, nil
+}
+
+// assumeRoleSession returns an AWS session using the default credential
+// chain, or credentials assumed via STS AssumeRole into roleArn when set.
+func assumeRoleSession(roleArn string) (*session.Session, error) {
+	sess, err := session.NewSession()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new AWS session")
 	}
-	costExplorer := costexplorer.New(session)
+	if roleArn == "" {
+		return sess, nil
+	}
+
+	creds := stscreds.NewCredentials(sess, roleArn)
+	return session.NewSession(&aws.Config{Credentials: creds})
+}
+
+type accountJob struct {
+	idx     int
+	account accountConfig
+}
+
+// getAccountCost assumes account's role (if any) and fetches its
+// month-to-date cost breakdown and end-of-month forecast.
+func getAccountCost(account accountConfig) (accountReport, error) {
+	sess, err := assumeRoleSession(account.RoleArn)
+	if err != nil {
+		return accountReport{}, errors.Wrapf(err, "failed to assume role for account %s", account.ID)
+	}
+	costExplorer := costexplorer.New(sess)
+
+	now := time.Now().In(time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	amounts, err := queryCostAndUsage(costExplorer, monthStart, tomorrow, "MONTHLY", "UnblendedCost", defaultGroupBy, nil)
+	if err != nil {
+		return accountReport{}, errors.Wrapf(err, "failed to get cost for account %s", account.ID)
+	}
+
+	costs := make([]cost, 0, len(amounts))
+	for key, amount := range amounts {
+		costs = append(costs, cost{key: key, unit: amount.unit, monthToDate: amount.amount})
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		return costs[i].monthToDate > costs[j].monthToDate
+	})
+
+	total := cost{key: "Total", unit: "*"}
+	for _, c := range costs {
+		total.monthToDate += c.monthToDate
+	}
+	costs = append([]cost{total}, costs...)
+
+	accountForecast, err := queryCostForecast(costExplorer, tomorrow, monthEnd)
+	if err != nil {
+		return accountReport{}, errors.Wrapf(err, "failed to get forecast for account %s", account.ID)
+	}
+
+	alias := account.Alias
+	if alias == "" {
+		alias = account.ID
+	}
+
+	return accountReport{alias: alias, costs: costs, forecast: accountForecast}, nil
+}
+
+// getAccountCosts fetches cost data for every account in ACS_ACCOUNTS
+// concurrently, bounded by accountWorkerPoolSize. Returns nil when
+// ACS_ACCOUNTS is unset. A single account failing to assume its role or
+// query Cost Explorer (e.g. a stale cross-account role) is logged and
+// dropped rather than failing the whole report, so one misconfigured
+// account doesn't take down the "All Accounts" rollup for the rest.
+func getAccountCosts() ([]accountReport, error) {
+	accounts, err := loadAccountConfigs()
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+
+	jobs := make(chan accountJob, len(accounts))
+	type result struct {
+		idx     int
+		account accountConfig
+		report  accountReport
+		err     error
+	}
+	results := make(chan result, len(accounts))
+
+	workers := accountWorkerPoolSize
+	if workers > len(accounts) {
+		workers = len(accounts)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				report, err := getAccountCost(job.account)
+				results <- result{idx: job.idx, account: job.account, report: report, err: err}
+			}
+		}()
+	}
+	for i, account := range accounts {
+		jobs <- accountJob{idx: i, account: account}
+	}
+	close(jobs)
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	reports := make([]accountReport, len(accounts))
+	ok := make([]bool, len(accounts))
+	for r := range results {
+		if r.err != nil {
+			log.WithError(r.err).WithField("account", r.account.ID).Warn("failed to get cost for account, dropping it from the report")
+			continue
+		}
+		reports[r.idx] = r.report
+		ok[r.idx] = true
+	}
+
+	succeeded := make([]accountReport, 0, len(reports))
+	for i, report := range reports {
+		if ok[i] {
+			succeeded = append(succeeded, report)
+		}
+	}
+
+	return succeeded, nil
+}
+
+// aggregateAccountCosts sums every account's Total row and forecast into a
+// single "All Accounts" summary.
+func aggregateAccountCosts(reports []accountReport) (cost, forecast) {
+	total := cost{key: "All Accounts", unit: "*"}
+	fc := forecast{}
+	for _, r := range reports {
+		for _, c := range r.costs {
+			if c.key != "Total" {
+				continue
+			}
+			total.monthToDate += c.monthToDate
+		}
+		fc.amount += r.forecast.amount
+		if fc.unit == "" {
+			fc.unit = r.forecast.unit
+		}
+	}
 
+	return total, fc
+}
+
+// accountAttachment renders one account's cost breakdown as a Slack
+// attachment.
+func accountAttachment(report accountReport) map[string]interface{} {
+	lines := make([]string, 0, len(report.costs)+1)
+	for _, c := range report.costs {
+		lines = append(lines, fmt.Sprintf("%-40s : %10.3f %s", trimmedKey(c.key), c.monthToDate, strings.TrimSpace(c.unit)))
+	}
+	if report.forecast.amount > 0 {
+		lines = append(lines, fmt.Sprintf("%-40s : %10.3f %s", "Forecast (end of month)", report.forecast.amount, strings.TrimSpace(report.forecast.unit)))
+	}
+
+	return map[string]interface{}{
+		"text": fmt.Sprintf("*Account: %s*\n```\n%s\n```", report.alias, strings.Join(lines, "\n")),
+	}
+}
+
+// allAccountsAttachment renders the aggregated "All Accounts" summary as a
+// Slack attachment.
+func allAccountsAttachment(total cost, fc forecast) map[string]interface{} {
+	lines := []string{fmt.Sprintf("%-40s : %10.3f %s", "Total", total.monthToDate, strings.TrimSpace(total.unit))}
+	if fc.amount > 0 {
+		lines = append(lines, fmt.Sprintf("%-40s : %10.3f %s", "Forecast (end of month)", fc.amount, strings.TrimSpace(fc.unit)))
+	}
+
+	return map[string]interface{}{
+		"text": fmt.Sprintf("*All Accounts*\n```\n%s\n```", strings.Join(lines, "\n")),
+	}
+}
+
+// renderServiceChartPNG renders a bar chart PNG of the top services by
+// month-to-date spend, excluding the synthetic Total row.
+func renderServiceChartPNG(details []cost) ([]byte, error) {
+	top := make([]cost, 0, chartServiceCount)
+	for _, c := range details {
+		if c.key == "Total" {
+			continue
+		}
+		top = append(top, c)
+		if len(top) >= chartServiceCount {
+			break
+		}
+	}
+
+	bars := make([]chart.Value, 0, len(top))
+	for _, c := range top {
+		bars = append(bars, chart.Value{Label: trimmedKey(c.key), Value: c.monthToDate})
+	}
+
+	graph := chart.BarChart{
+		Title:      "AWS Cost by Service (month to date)",
+		Height:     512,
+		Width:      1024,
+		BarWidth:   40,
+		Background: chart.Style{Padding: chart.Box{Top: 40}},
+		Bars:       bars,
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := graph.Render(chart.PNG, buf); err != nil {
+		return nil, errors.Wrap(err, "failed to render chart")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderDailySpendSparklinePNG renders a sparkline of total daily spend from
+// the start of the current month through today.
+func renderDailySpendSparklinePNG(costExplorer *costexplorer.CostExplorer) ([]byte, error) {
 	now := time.Now().In(time.UTC)
-	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 1, 0)
-	dateFormat := "2006-01-02"
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	amounts, _, err := queryDailyTotalSeries(costExplorer, monthStart, tomorrow)
+	if err != nil {
+		return nil, err
+	}
+
+	xValues := make([]float64, len(amounts))
+	for i := range amounts {
+		xValues[i] = float64(i + 1)
+	}
+
+	graph := chart.Chart{
+		Title:  "Daily Spend (month to date)",
+		Height: 256,
+		Width:  1024,
+		XAxis:  chart.XAxis{Style: chart.Style{Hidden: true}},
+		YAxis:  chart.YAxis{Style: chart.Style{Hidden: true}},
+		Series: []chart.Series{
+			chart.ContinuousSeries{XValues: xValues, YValues: amounts},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := graph.Render(chart.PNG, buf); err != nil {
+		return nil, errors.Wrap(err, "failed to render chart")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// postSlackChart renders the service cost breakdown and the daily spend
+// sparkline as PNGs and uploads both to channelName via Slack's files.upload
+// API, which requires a bot token rather than the incoming webhook used by
+// postSlack.
+func postSlackChart(costExplorer *costexplorer.CostExplorer, token string, channelName string, details []cost) error {
+	servicePNG, err := renderServiceChartPNG(details)
+	if err != nil {
+		return err
+	}
+	if err := uploadSlackFile(token, channelName, "AWS Cost and Usage", "cost.png", servicePNG); err != nil {
+		return err
+	}
+
+	sparklinePNG, err := renderDailySpendSparklinePNG(costExplorer)
+	if err != nil {
+		return errors.Wrap(err, "failed to render daily spend sparkline")
+	}
+
+	return uploadSlackFile(token, channelName, "Daily Spend (month to date)", "daily-spend.png", sparklinePNG)
+}
+
+// uploadSlackFile posts content to Slack's files.upload API as a multipart
+// form, the only mechanism Slack supports for attaching images to messages.
+func uploadSlackFile(token string, channelName string, title string, filename string, content []byte) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.WriteField("channels", channelName); err != nil {
+		return errors.Wrap(err, "failed to write channels field")
+	}
+	if err := writer.WriteField("title", title); err != nil {
+		return errors.Wrap(err, "failed to write title field")
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return errors.Wrap(err, "failed to create form file")
+	}
+	if _, err := io.Copy(part, bytes.NewReader(content)); err != nil {
+		return errors.Wrap(err, "failed to copy file content")
+	}
+	if err := writer.Close(); err != nil {
+		return errors.Wrap(err, "failed to close multipart writer")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://slack.com/api/files.upload", body)
+	if err != nil {
+		return errors.Wrap(err, "failed to build files.upload request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send files.upload request")
+	}
+	defer resp.Body.Close()
+
+	respBodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read files.upload response body")
+	}
+
+	var out struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(respBodyBytes, &out); err != nil {
+		return errors.Wrap(err, "failed to parse files.upload response")
+	}
+
+	log.WithFields(log.Fields{"res body": string(respBodyBytes), "status": resp.Status}).Info("slack files.upload")
+
+	if !out.OK {
+		return errors.Errorf("files.upload failed: %s", out.Error)
+	}
+
+	return nil
+}
+
+// loadSectionConfigs reads additional report sections from ACS_CONFIG
+// (inline JSON) or, failing that, from the file named by ACS_CONFIG_FILE.
+// Returns nil when neither is set, leaving the default report unchanged.
+func loadSectionConfigs() ([]sectionConfig, error) {
+	raw := os.Getenv("ACS_CONFIG")
+	if raw == "" {
+		path := os.Getenv("ACS_CONFIG_FILE")
+		if path == "" {
+			return nil, nil
+		}
+
+		fileBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read config file %s", path)
+		}
+		raw = string(fileBytes)
+	}
+
+	sections := make([]sectionConfig, 0)
+	if err := json.Unmarshal([]byte(raw), &sections); err != nil {
+		return nil, errors.Wrap(err, "failed to parse section config")
+	}
+
+	return sections, nil
+}
+
+const dateFormat = "2006-01-02"
+
+// defaultGroupBy groups by SERVICE, matching the tool's original behavior.
+var defaultGroupBy = []*costexplorer.GroupDefinition{
+	{
+		Key:  aws.String("SERVICE"),
+		Type: aws.String("DIMENSION"),
+	},
+}
+
+// queryCostAndUsage calls costexplorer.GetCostAndUsage for the given period,
+// granularity, group-by dimensions and filter, and returns the metric's
+// amount per group key (multiple GroupBy dimensions are joined with " / ").
+// Multiple ResultsByTime buckets (e.g. several DAILY periods) are summed per
+// key.
+func queryCostAndUsage(costExplorer *costexplorer.CostExplorer, start time.Time, end time.Time, granularity string, metric string, groupBy []*costexplorer.GroupDefinition, filter *costexplorer.Expression) (map[string]costAmount, error) {
 	in := costexplorer.GetCostAndUsageInput{
 		TimePeriod: &costexplorer.DateInterval{
-			Start: aws.String(startDate.Format(dateFormat)),
-			End:   aws.String(endDate.Format(dateFormat)),
-		},
-		Metrics:     []*string{aws.String("UnblendedCost")},
-		Granularity: aws.String("MONTHLY"),
-		GroupBy: []*costexplorer.GroupDefinition{
-			{
-				Key:  aws.String("SERVICE"),
-				Type: aws.String("DIMENSION"),
-			},
+			Start: aws.String(start.Format(dateFormat)),
+			End:   aws.String(end.Format(dateFormat)),
 		},
+		Metrics:     []*string{aws.String(metric)},
+		Granularity: aws.String(granularity),
+		GroupBy:     groupBy,
+		Filter:      filter,
 	}
 	out, err := costExplorer.GetCostAndUsage(&in)
 	if err != nil {
@@ -98,22 +682,19 @@ func getCosts() ([]cost, error) {
 	}
 	log.WithFields(log.Fields{"in": in, "out": *out}).Info("cost and usage")
 
-	costs := make([]cost, 0)
+	amounts := make(map[string]costAmount)
 	for _, result := range out.ResultsByTime {
 		for _, group := range result.Groups {
-			key := ""
-			if len(group.Keys) >= 1 {
-				key = *group.Keys[0]
-			}
+			key := strings.Join(aws.StringValueSlice(group.Keys), " / ")
 
 			var unit, amount string
-			metric := group.Metrics["UnblendedCost"]
-			if metric != nil {
-				if metric.Amount != nil {
-					amount = *metric.Amount
+			metricOut := group.Metrics[metric]
+			if metricOut != nil {
+				if metricOut.Amount != nil {
+					amount = *metricOut.Amount
 				}
-				if metric.Unit != nil {
-					unit = *metric.Unit
+				if metricOut.Unit != nil {
+					unit = *metricOut.Unit
 				}
 			}
 
@@ -122,69 +703,1077 @@ func getCosts() ([]cost, error) {
 				return nil, errors.Wrap(err, "failed to parse amount")
 			}
 
-			costs = append(costs, cost{
-				key:    key,
-				amount: amountVal,
-				unit:   unit,
-			})
+			existing := amounts[key]
+			amounts[key] = costAmount{amount: existing.amount + amountVal, unit: unit}
 		}
 	}
-	sort.Slice(costs, func(i, j int) bool {
-		return costs[i].amount > costs[j].amount
-	})
-
-	total := float64(0)
-	for _, c := range costs {
-		total += c.amount
-	}
-	costs = append(
-		[]cost{{key: "Total", amount: total, unit: "*"}},
-		costs...,
-	)
 
-	return costs, nil
+	return amounts, nil
 }
 
-func postSlack(webhookURL string, channelName string, details []cost) error {
-	texts := make([]string, 0)
-	for _, detail := range details {
-		key := strings.TrimSpace(
-			strings.NewReplacer("AWS", "", "Amazon", "").Replace(detail.key),
-		)
-		unit := strings.TrimSpace(detail.unit)
-		texts = append(texts, fmt.Sprintf("%-40s : %10.3f %s", key, detail.amount, unit))
-	}
-	text := fmt.Sprintf("```\n%s\n```", strings.Join(texts, "\n"))
-
-	req := map[string]interface{}{
-		"text":        "AWS Cost and Usage",
-		"channelName": channelName,
-		"attachments": []map[string]interface{}{
-			{
-				"text": text,
-			},
+// queryDailyCostSeries calls costexplorer.GetCostAndUsage at DAILY
+// granularity grouped by SERVICE and returns each service's per-day amounts
+// in chronological order, along with the metric's unit.
+func queryDailyCostSeries(costExplorer *costexplorer.CostExplorer, start time.Time, end time.Time) (map[string][]float64, string, error) {
+	in := costexplorer.GetCostAndUsageInput{
+		TimePeriod: &costexplorer.DateInterval{
+			Start: aws.String(start.Format(dateFormat)),
+			End:   aws.String(end.Format(dateFormat)),
 		},
+		Metrics:     []*string{aws.String("UnblendedCost")},
+		Granularity: aws.String("DAILY"),
+		GroupBy:     defaultGroupBy,
 	}
-	reqBytes, err := json.Marshal(req)
+	out, err := costExplorer.GetCostAndUsage(&in)
 	if err != nil {
-		return errors.Wrapf(err, "failed to serialize request. %+v", req)
+		return nil, "", errors.Wrapf(err, "failed to get cost and usage %+v", in)
 	}
+	log.WithFields(log.Fields{"in": in, "out": *out}).Info("daily cost series")
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return errors.Wrap(err, "failed to send request")
-	}
+	series := make(map[string][]float64)
+	unit := ""
+	for _, result := range out.ResultsByTime {
+		for _, group := range result.Groups {
+			key := strings.Join(aws.StringValueSlice(group.Keys), " / ")
 
-	respBodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return errors.Wrap(err, "failed to read response body")
-	}
+			var amount string
+			metricOut := group.Metrics["UnblendedCost"]
+			if metricOut != nil {
+				if metricOut.Amount != nil {
+					amount = *metricOut.Amount
+				}
+				if metricOut.Unit != nil {
+					unit = *metricOut.Unit
+				}
+			}
 
-	log.WithFields(log.Fields{"req body": req, "res body": respBodyBytes, "status": resp.Status}).Info("slack")
+			amountVal, err := strconv.ParseFloat(amount, 64)
+			if err != nil {
+				return nil, "", errors.Wrap(err, "failed to parse amount")
+			}
 
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(fmt.Sprintf("invalid status %s", resp.Status))
+			series[key] = append(series[key], amountVal)
+		}
+	}
+
+	return series, unit, nil
+}
+
+// queryDailyTotalSeries calls costexplorer.GetCostAndUsage at DAILY
+// granularity with no GroupBy and returns the account's total spend for each
+// day in chronological order, along with the metric's unit.
+func queryDailyTotalSeries(costExplorer *costexplorer.CostExplorer, start time.Time, end time.Time) ([]float64, string, error) {
+	in := costexplorer.GetCostAndUsageInput{
+		TimePeriod: &costexplorer.DateInterval{
+			Start: aws.String(start.Format(dateFormat)),
+			End:   aws.String(end.Format(dateFormat)),
+		},
+		Metrics:     []*string{aws.String("UnblendedCost")},
+		Granularity: aws.String("DAILY"),
+	}
+	out, err := costExplorer.GetCostAndUsage(&in)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get cost and usage %+v", in)
+	}
+	log.WithFields(log.Fields{"in": in, "out": *out}).Info("daily total series")
+
+	amounts := make([]float64, 0, len(out.ResultsByTime))
+	unit := ""
+	for _, result := range out.ResultsByTime {
+		var amount string
+		if metricOut := result.Total["UnblendedCost"]; metricOut != nil {
+			if metricOut.Amount != nil {
+				amount = *metricOut.Amount
+			}
+			if metricOut.Unit != nil {
+				unit = *metricOut.Unit
+			}
+		}
+
+		amountVal, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to parse amount")
+		}
+
+		amounts = append(amounts, amountVal)
+	}
+
+	return amounts, unit, nil
+}
+
+// meanStdDev returns the population mean and standard deviation of values.
+func meanStdDev(values []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return mean, math.Sqrt(variance)
+}
+
+// getAnomalies compares yesterday's actual spend per service, taken from
+// details (already fetched by getCosts, so this doesn't requery it), against
+// a trailing anomalyBaselineDays baseline (mean + standard deviation) and
+// flags services whose spend is anomalySigma standard deviations above
+// baseline, or whose relative jump exceeds anomalyMaxJumpPercent. The
+// baseline is recomputed from Cost Explorer on every invocation; nothing is
+// persisted between runs.
+func getAnomalies(costExplorer *costexplorer.CostExplorer, details []cost) ([]anomaly, error) {
+	now := time.Now().In(time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	yesterdayStart := today.AddDate(0, 0, -1)
+
+	baselineEnd := yesterdayStart
+	baselineStart := baselineEnd.AddDate(0, 0, -anomalyBaselineDays)
+	baseline, unit, err := queryDailyCostSeries(costExplorer, baselineStart, baselineEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := make([]anomaly, 0)
+	for _, c := range details {
+		if c.key == "Total" {
+			continue
+		}
+		if a, ok := evaluateAnomaly(c, baseline[c.key], unit); ok {
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		return anomalies[i].zScore > anomalies[j].zScore
+	})
+
+	return anomalies, nil
+}
+
+// evaluateAnomaly scores a service's actual spend (c.yesterday) against its
+// trailing baseline series and returns ok=false if it isn't anomalous:
+// below anomalyMinUSD, too few baseline samples, fewer than anomalySigma
+// standard deviations above the baseline mean, and not jumping by
+// anomalyMaxJumpPercent or more. A perfectly flat baseline has a standard
+// deviation of 0, so the sigma formula degenerates to 0 (and for an all-zero
+// baseline the percent-jump formula does too); any spend above such a
+// baseline is flagged as a hard anomaly instead of relying on either
+// formula. baselineUnit backstops c.unit for
+// services with no month-to-date spend of their own.
+func evaluateAnomaly(c cost, series []float64, baselineUnit string) (anomaly, bool) {
+	if c.yesterday < anomalyMinUSD {
+		return anomaly{}, false
+	}
+	if len(series) < anomalyMinSamples {
+		return anomaly{}, false
+	}
+
+	mean, stdDev := meanStdDev(series)
+
+	zScore := 0.0
+	if stdDev > 0 {
+		zScore = (c.yesterday - mean) / stdDev
+	}
+	flatBaselineSpike := stdDev == 0 && c.yesterday > mean
+
+	jumpExceeded := anomalyMaxJumpPercent > 0 && percentChange(c.yesterday, mean) >= anomalyMaxJumpPercent
+	if !flatBaselineSpike && zScore < anomalySigma && !jumpExceeded {
+		return anomaly{}, false
+	}
+
+	unit := c.unit
+	if unit == "" {
+		unit = baselineUnit
+	}
+
+	return anomaly{
+		key:            c.key,
+		unit:           unit,
+		amount:         c.yesterday,
+		baselineMean:   mean,
+		baselineStdDev: stdDev,
+		zScore:         zScore,
+	}, true
+}
+
+// queryCostForecast calls costexplorer.GetCostForecast for the rest of the
+// current month. The forecast endpoint does not support GroupBy, so this
+// always returns a single account-wide total.
+func queryCostForecast(costExplorer *costexplorer.CostExplorer, start time.Time, end time.Time) (forecast, error) {
+	in := costexplorer.GetCostForecastInput{
+		TimePeriod: &costexplorer.DateInterval{
+			Start: aws.String(start.Format(dateFormat)),
+			End:   aws.String(end.Format(dateFormat)),
+		},
+		Metric:      aws.String("UNBLENDED_COST"),
+		Granularity: aws.String("MONTHLY"),
+	}
+	out, err := costExplorer.GetCostForecast(&in)
+	if err != nil {
+		return forecast{}, errors.Wrapf(err, "failed to get cost forecast %+v", in)
+	}
+	log.WithFields(log.Fields{"in": in, "out": *out}).Info("cost forecast")
+
+	if out.Total == nil || out.Total.Amount == nil {
+		return forecast{}, nil
+	}
+
+	amount, err := strconv.ParseFloat(*out.Total.Amount, 64)
+	if err != nil {
+		return forecast{}, errors.Wrap(err, "failed to parse forecast amount")
+	}
+
+	unit := ""
+	if out.Total.Unit != nil {
+		unit = *out.Total.Unit
+	}
+
+	return forecast{amount: amount, unit: unit}, nil
+}
+
+// newCostExplorer creates a standalone AWS session and Cost Explorer client
+// for callers that don't already have one to share, such as the HTTP
+// handlers and slash-command path, each of which runs independently of run's
+// scheduled invocation.
+func newCostExplorer() (*costexplorer.CostExplorer, error) {
+	session, err := session.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create new AWS session")
+	}
+	return costexplorer.New(session), nil
+}
+
+func getCosts(costExplorer *costexplorer.CostExplorer) ([]cost, forecast, error) {
+	now := time.Now().In(time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	elapsedDays := int(tomorrow.Sub(monthStart).Hours() / 24)
+	priorMonthStart := monthStart.AddDate(0, -1, 0)
+	priorMonthToDateEnd := priorMonthStart.AddDate(0, 0, elapsedDays)
+
+	monthToDate, err := queryCostAndUsage(costExplorer, monthStart, tomorrow, "MONTHLY", "UnblendedCost", defaultGroupBy, nil)
+	if err != nil {
+		return nil, forecast{}, err
+	}
+	priorMonthToDate, err := queryCostAndUsage(costExplorer, priorMonthStart, priorMonthToDateEnd, "MONTHLY", "UnblendedCost", defaultGroupBy, nil)
+	if err != nil {
+		return nil, forecast{}, err
+	}
+	yesterday, err := queryCostAndUsage(costExplorer, today.AddDate(0, 0, -1), today, "DAILY", "UnblendedCost", defaultGroupBy, nil)
+	if err != nil {
+		return nil, forecast{}, err
+	}
+	dayBeforeYesterday, err := queryCostAndUsage(costExplorer, today.AddDate(0, 0, -2), today.AddDate(0, 0, -1), "DAILY", "UnblendedCost", defaultGroupBy, nil)
+	if err != nil {
+		return nil, forecast{}, err
+	}
+	monthForecast, err := queryCostForecast(costExplorer, tomorrow, monthEnd)
+	if err != nil {
+		return nil, forecast{}, err
+	}
+
+	keys := make(map[string]bool)
+	for key := range monthToDate {
+		keys[key] = true
+	}
+	for key := range priorMonthToDate {
+		keys[key] = true
+	}
+	for key := range yesterday {
+		keys[key] = true
+	}
+	for key := range dayBeforeYesterday {
+		keys[key] = true
+	}
+
+	costs := make([]cost, 0, len(keys))
+	for key := range keys {
+		unit := monthToDate[key].unit
+		if unit == "" {
+			unit = yesterday[key].unit
+		}
+
+		costs = append(costs, cost{
+			key:                key,
+			unit:               unit,
+			monthToDate:        monthToDate[key].amount,
+			priorMonthToDate:   priorMonthToDate[key].amount,
+			yesterday:          yesterday[key].amount,
+			dayBeforeYesterday: dayBeforeYesterday[key].amount,
+		})
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		return costs[i].monthToDate > costs[j].monthToDate
+	})
+
+	total := cost{key: "Total", unit: "*"}
+	for _, c := range costs {
+		total.monthToDate += c.monthToDate
+		total.priorMonthToDate += c.priorMonthToDate
+		total.yesterday += c.yesterday
+		total.dayBeforeYesterday += c.dayBeforeYesterday
+	}
+	costs = append([]cost{total}, costs...)
+
+	return costs, monthForecast, nil
+}
+
+type sectionResult struct {
+	name  string
+	costs []cost
+}
+
+// getSectionCosts runs one GetCostAndUsage query per configured section for
+// the current month to date and returns each section's cost breakdown,
+// keeping sections in the order they were declared.
+func getSectionCosts(costExplorer *costexplorer.CostExplorer, sections []sectionConfig) ([]sectionResult, error) {
+	if len(sections) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().In(time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	results := make([]sectionResult, 0, len(sections))
+	for _, section := range sections {
+		granularity := section.Granularity
+		if granularity == "" {
+			granularity = "MONTHLY"
+		}
+		metric := section.Metric
+		if metric == "" {
+			metric = "UnblendedCost"
+		}
+		groupBy := defaultGroupBy
+		if len(section.GroupBy) > 0 {
+			groupBy = make([]*costexplorer.GroupDefinition, 0, len(section.GroupBy))
+			for _, g := range section.GroupBy {
+				groupBy = append(groupBy, &costexplorer.GroupDefinition{
+					Key:  aws.String(g.Key),
+					Type: aws.String(g.Type),
+				})
+			}
+		}
+
+		amounts, err := queryCostAndUsage(costExplorer, monthStart, tomorrow, granularity, metric, groupBy, section.Filter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get cost for section %s", section.Name)
+		}
+
+		costs := make([]cost, 0, len(amounts))
+		for key, amount := range amounts {
+			costs = append(costs, cost{key: key, unit: amount.unit, monthToDate: amount.amount})
+		}
+		sort.Slice(costs, func(i, j int) bool {
+			return costs[i].monthToDate > costs[j].monthToDate
+		})
+
+		results = append(results, sectionResult{name: section.Name, costs: costs})
+	}
+
+	return results, nil
+}
+
+// sectionAttachment renders a configured section's cost breakdown as a
+// Slack attachment.
+func sectionAttachment(section sectionResult) map[string]interface{} {
+	lines := make([]string, 0, len(section.costs))
+	for _, c := range section.costs {
+		lines = append(lines, fmt.Sprintf("%-40s : %10.3f %s", trimmedKey(c.key), c.monthToDate, strings.TrimSpace(c.unit)))
+	}
+
+	return map[string]interface{}{
+		"text": fmt.Sprintf("*%s*\n```\n%s\n```", section.name, strings.Join(lines, "\n")),
+	}
+}
+
+// percentChange returns the percentage change from prior to current, or 0
+// when prior is zero (avoids a division by zero for brand new services).
+func percentChange(current float64, prior float64) float64 {
+	if prior == 0 {
+		return 0
+	}
+	return (current - prior) / prior * 100
+}
+
+// biggestMovers returns the top n costs (excluding the Total row) ranked by
+// the absolute month-over-month change in spend.
+func biggestMovers(costs []cost, n int) []cost {
+	candidates := make([]cost, 0, len(costs))
+	for _, c := range costs {
+		if c.key == "Total" {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return math.Abs(candidates[i].monthToDate-candidates[i].priorMonthToDate) >
+			math.Abs(candidates[j].monthToDate-candidates[j].priorMonthToDate)
+	})
+
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+
+	return candidates[:n]
+}
+
+// getBudgetAlerts fetches the account's AWS Budgets, correlates each budget
+// with its matching cost detail via matchBudgetCost, and returns the ones
+// that have crossed one of budgetThresholds. See matchBudgetCost for which
+// budget scopes can actually be correlated.
+func getBudgetAlerts(sess *session.Session, details []cost) ([]budgetAlert, error) {
+	if accountID == "" {
+		log.Info("ACS_AWS_ACCOUNT_ID is not set, skipping budget alerts")
+		return nil, nil
+	}
+
+	client := budgets.New(sess)
+
+	in := budgets.DescribeBudgetsInput{
+		AccountId: aws.String(accountID),
+	}
+	out, err := client.DescribeBudgets(&in)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe budgets %+v", in)
+	}
+	log.WithFields(log.Fields{"in": in, "out": *out}).Info("budgets")
+
+	costByKey := make(map[string]cost, len(details))
+	for _, d := range details {
+		costByKey[d.key] = d
+	}
+
+	alerts := make([]budgetAlert, 0)
+	for _, b := range out.Budgets {
+		if b.BudgetName == nil || b.BudgetLimit == nil || b.BudgetLimit.Amount == nil {
+			continue
+		}
+
+		unit := ""
+		if b.BudgetLimit.Unit != nil {
+			unit = *b.BudgetLimit.Unit
+		}
+
+		limit, err := strconv.ParseFloat(*b.BudgetLimit.Amount, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse budget limit")
+		}
+
+		var actual, forecast float64
+		if b.CalculatedSpend != nil {
+			if spend := b.CalculatedSpend.ActualSpend; spend != nil && spend.Amount != nil {
+				actual, err = strconv.ParseFloat(*spend.Amount, 64)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to parse actual spend")
+				}
+			}
+			if spend := b.CalculatedSpend.ForecastedSpend; spend != nil && spend.Amount != nil {
+				forecast, err = strconv.ParseFloat(*spend.Amount, 64)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to parse forecasted spend")
+				}
+			}
+		}
+
+		// Prefer the correlated cost detail's key for a nicer display name,
+		// but fall back to the budget's own name when nothing matches.
+		name := *b.BudgetName
+		if matched, ok := matchBudgetCost(b, costByKey); ok {
+			name = matched.key
+		}
+
+		alert, ok := evaluateBudgetAlert(name, limit, actual, forecast, unit)
+		if ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts, nil
+}
+
+// budgetCostFilterDimensions lists the AWS Budgets CostFilters keys, in
+// preference order, that map onto a single Cost Explorer group key the way
+// this tool groups cost details (see defaultGroupBy). details is always
+// grouped by SERVICE, so in practice only Service-scoped budgets correlate
+// today; LinkedAccount and UsageType are listed for when a caller passes in
+// cost details grouped by one of those dimensions instead. Tag-scoped
+// budgets (CostFilters["TagKeyValue"]) are deliberately not covered: a tag
+// value never appears as a key in SERVICE-grouped cost details, so a match
+// against it could only come from a coincidentally equal string. Those
+// budgets keep falling back to their own BudgetName.
+var budgetCostFilterDimensions = []string{"Service", "LinkedAccount", "UsageType"}
+
+// matchBudgetCost correlates a budget with the cost detail it tracks by
+// inspecting its CostFilters rather than guessing from its display name:
+// a budget scoped to a single value of a known dimension (e.g.
+// CostFilters["Service"] = ["Amazon Elastic Compute Cloud - Compute"])
+// correlates with the cost detail sharing that same group key. See
+// budgetCostFilterDimensions for which dimensions are covered.
+func matchBudgetCost(b *budgets.Budget, costByKey map[string]cost) (cost, bool) {
+	if b.CostFilters == nil {
+		return cost{}, false
+	}
+
+	for _, dimension := range budgetCostFilterDimensions {
+		values, ok := b.CostFilters[dimension]
+		if !ok || len(values) != 1 || values[0] == nil {
+			continue
+		}
+		if c, ok := costByKey[*values[0]]; ok {
+			return c, true
+		}
+	}
+
+	return cost{}, false
+}
+
+// evaluateBudgetAlert returns the highest budgetThreshold crossed by either
+// actual or forecasted spend, or ok=false if none was crossed.
+func evaluateBudgetAlert(name string, limit float64, actual float64, forecast float64, unit string) (budgetAlert, bool) {
+	if limit <= 0 {
+		return budgetAlert{}, false
+	}
+
+	percent := actual / limit * 100
+	forecastPercent := forecast / limit * 100
+
+	crossed := float64(0)
+	forecasted := false
+	for _, threshold := range budgetThresholds {
+		if percent >= threshold {
+			crossed = threshold
+			forecasted = false
+		} else if forecastPercent >= threshold {
+			crossed = threshold
+			forecasted = true
+		}
+	}
+	if crossed == 0 {
+		return budgetAlert{}, false
+	}
+
+	return budgetAlert{
+		name:       name,
+		limit:      limit,
+		actual:     actual,
+		forecast:   forecast,
+		unit:       unit,
+		threshold:  crossed,
+		percent:    percent,
+		forecasted: forecasted,
+		color:      budgetAlertColor(crossed),
+	}, true
+}
+
+// budgetAlertColor maps a crossed threshold to a Slack attachment color.
+func budgetAlertColor(threshold float64) string {
+	switch {
+	case threshold >= 100:
+		return "danger"
+	case threshold >= 80:
+		return "warning"
+	default:
+		return "good"
+	}
+}
+
+// budgetAlertAttachment renders the "over budget" Slack attachment for the
+// given alerts, or ok=false when there is nothing to report.
+func budgetAlertAttachment(alerts []budgetAlert) (map[string]interface{}, bool) {
+	if len(alerts) == 0 {
+		return nil, false
+	}
+
+	color := "good"
+	lines := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		if a.color == "danger" || (color != "danger" && a.color == "warning") {
+			color = a.color
+		}
+
+		basis := "actual"
+		amount := a.actual
+		if a.forecasted {
+			basis = "forecasted"
+			amount = a.forecast
+		}
+		lines = append(lines, fmt.Sprintf(
+			"%-30s : %.0f%% of budget crossed (%s %10.3f %s / %10.3f %s)",
+			a.name, a.threshold, basis, amount, a.unit, a.limit, a.unit,
+		))
+	}
+
+	mention := ""
+	if alertMention != "" {
+		mention = alertMention + " "
+	}
+
+	return map[string]interface{}{
+		"color": color,
+		"text":  fmt.Sprintf("%s*Budget Alerts*\n```\n%s\n```", mention, strings.Join(lines, "\n")),
+	}, true
+}
+
+// trimmedKey strips the common "AWS"/"Amazon" prefixes Slack doesn't need
+// to fit more into the fixed-width table.
+func trimmedKey(key string) string {
+	return strings.TrimSpace(
+		strings.NewReplacer("AWS", "", "Amazon", "").Replace(key),
+	)
+}
+
+const moversCount = 5
+
+// moversAttachment renders a "biggest movers" Slack attachment for the
+// services with the largest absolute month-over-month change, or ok=false
+// when there is nothing to report.
+func moversAttachment(details []cost) (map[string]interface{}, bool) {
+	movers := biggestMovers(details, moversCount)
+	if len(movers) == 0 {
+		return nil, false
+	}
+
+	lines := make([]string, 0, len(movers))
+	for _, m := range movers {
+		key := trimmedKey(m.key)
+		delta := m.monthToDate - m.priorMonthToDate
+		lines = append(lines, fmt.Sprintf(
+			"%-40s : %+10.3f %s (%+7.1f%%)",
+			key, delta, strings.TrimSpace(m.unit), percentChange(m.monthToDate, m.priorMonthToDate),
+		))
+	}
+
+	return map[string]interface{}{
+		"text": fmt.Sprintf("*Biggest Movers*\n```\n%s\n```", strings.Join(lines, "\n")),
+	}, true
+}
+
+// anomalyAttachment renders the "Anomalies" Slack attachment for the given
+// flagged services, or ok=false when there is nothing to report.
+func anomalyAttachment(anomalies []anomaly) (map[string]interface{}, bool) {
+	if len(anomalies) == 0 {
+		return nil, false
+	}
+
+	lines := make([]string, 0, len(anomalies))
+	for _, a := range anomalies {
+		lines = append(lines, fmt.Sprintf(
+			"%-30s : %10.3f %s (z=%.1f, baseline %10.3f ± %8.3f %s)",
+			trimmedKey(a.key), a.amount, strings.TrimSpace(a.unit), a.zScore, a.baselineMean, a.baselineStdDev, strings.TrimSpace(a.unit),
+		))
+	}
+
+	return map[string]interface{}{
+		"color": "warning",
+		"text":  fmt.Sprintf("*Anomalies*\n```\n%s\n```", strings.Join(lines, "\n")),
+	}, true
+}
+
+// formatCostText renders details and monthForecast as the fixed-width code
+// block table used both in the Slack report and in on-demand HTTP/slash
+// command responses.
+func formatCostText(details []cost, monthForecast forecast) string {
+	texts := make([]string, 0, len(details)+1)
+	for _, detail := range details {
+		key := trimmedKey(detail.key)
+		unit := strings.TrimSpace(detail.unit)
+		texts = append(texts, fmt.Sprintf(
+			"%-40s : %10.3f %s (MoM %+7.1f%%, DoD %+7.1f%%)",
+			key, detail.monthToDate, unit,
+			percentChange(detail.monthToDate, detail.priorMonthToDate),
+			percentChange(detail.yesterday, detail.dayBeforeYesterday),
+		))
+	}
+	if monthForecast.amount > 0 {
+		texts = append(texts, fmt.Sprintf("%-40s : %10.3f %s", "Forecast (end of month)", monthForecast.amount, strings.TrimSpace(monthForecast.unit)))
+	}
+
+	return fmt.Sprintf("```\n%s\n```", strings.Join(texts, "\n"))
+}
+
+func postSlack(webhookURL string, channelName string, details []cost, monthForecast forecast, alerts []budgetAlert, sections []sectionResult, anomalies []anomaly, accountReports []accountReport) error {
+	attachments := []map[string]interface{}{
+		{
+			"text": formatCostText(details, monthForecast),
+		},
+	}
+	if attachment, ok := moversAttachment(details); ok {
+		attachments = append(attachments, attachment)
+	}
+	if attachment, ok := budgetAlertAttachment(alerts); ok {
+		attachments = append(attachments, attachment)
+	}
+	if attachment, ok := anomalyAttachment(anomalies); ok {
+		attachments = append(attachments, attachment)
+	}
+	for _, section := range sections {
+		attachments = append(attachments, sectionAttachment(section))
+	}
+	if len(accountReports) > 0 {
+		allAccountsTotal, allAccountsForecast := aggregateAccountCosts(accountReports)
+		attachments = append(attachments, allAccountsAttachment(allAccountsTotal, allAccountsForecast))
+		for _, report := range accountReports {
+			attachments = append(attachments, accountAttachment(report))
+		}
+	}
+
+	req := map[string]interface{}{
+		"text":        "AWS Cost and Usage",
+		"channelName": channelName,
+		"attachments": attachments,
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize request. %+v", req)
+	}
+
+	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+
+	respBodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	log.WithFields(log.Fields{"req body": req, "res body": respBodyBytes, "status": resp.Status}).Info("slack")
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("invalid status %s", resp.Status))
 	}
 
 	return nil
 }
+
+type (
+	costView struct {
+		Key                   string  `json:"key"`
+		Unit                  string  `json:"unit"`
+		MonthToDate           float64 `json:"monthToDate"`
+		PriorMonthToDate      float64 `json:"priorMonthToDate"`
+		Yesterday             float64 `json:"yesterday"`
+		DayBeforeYesterday    float64 `json:"dayBeforeYesterday"`
+		MonthOverMonthPercent float64 `json:"monthOverMonthPercent"`
+		DayOverDayPercent     float64 `json:"dayOverDayPercent"`
+	}
+
+	forecastView struct {
+		Amount float64 `json:"amount"`
+		Unit   string  `json:"unit"`
+	}
+)
+
+func toCostView(c cost) costView {
+	return costView{
+		Key:                   trimmedKey(c.key),
+		Unit:                  strings.TrimSpace(c.unit),
+		MonthToDate:           c.monthToDate,
+		PriorMonthToDate:      c.priorMonthToDate,
+		Yesterday:             c.yesterday,
+		DayBeforeYesterday:    c.dayBeforeYesterday,
+		MonthOverMonthPercent: percentChange(c.monthToDate, c.priorMonthToDate),
+		DayOverDayPercent:     percentChange(c.yesterday, c.dayBeforeYesterday),
+	}
+}
+
+func toForecastView(f forecast) forecastView {
+	return forecastView{Amount: f.amount, Unit: strings.TrimSpace(f.unit)}
+}
+
+// startServer runs the tool in "server" mode: an HTTP listener serving
+// on-demand cost queries and the Slack slash-command endpoint, as an
+// alternative to the Lambda/local one-shot modes.
+func startServer() error {
+	addr := httpAddr
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cost", handleCost)
+	mux.HandleFunc("/cost/forecast", handleCostForecast)
+	mux.HandleFunc("/cost/", handleCostService)
+	mux.HandleFunc("/slack/command", handleSlackCommand)
+
+	log.WithField("addr", addr).Info("starting http server")
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("failed to write json response")
+	}
+}
+
+func handleCost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	costExplorer, err := newCostExplorer()
+	if err != nil {
+		log.WithError(err).Error("failed to get cost")
+		http.Error(w, "failed to get cost", http.StatusInternalServerError)
+		return
+	}
+
+	details, monthForecast, err := getCosts(costExplorer)
+	if err != nil {
+		log.WithError(err).Error("failed to get cost")
+		http.Error(w, "failed to get cost", http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]costView, 0, len(details))
+	for _, c := range details {
+		views = append(views, toCostView(c))
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"costs":    views,
+		"forecast": toForecastView(monthForecast),
+	})
+}
+
+func handleCostForecast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	costExplorer, err := newCostExplorer()
+	if err != nil {
+		log.WithError(err).Error("failed to get cost forecast")
+		http.Error(w, "failed to get cost forecast", http.StatusInternalServerError)
+		return
+	}
+
+	_, monthForecast, err := getCosts(costExplorer)
+	if err != nil {
+		log.WithError(err).Error("failed to get cost forecast")
+		http.Error(w, "failed to get cost forecast", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, toForecastView(monthForecast))
+}
+
+func handleCostService(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	service := strings.TrimPrefix(r.URL.Path, "/cost/")
+	if service == "" || service == "forecast" {
+		http.NotFound(w, r)
+		return
+	}
+
+	costExplorer, err := newCostExplorer()
+	if err != nil {
+		log.WithError(err).Error("failed to get cost")
+		http.Error(w, "failed to get cost", http.StatusInternalServerError)
+		return
+	}
+
+	details, _, err := getCosts(costExplorer)
+	if err != nil {
+		log.WithError(err).Error("failed to get cost")
+		http.Error(w, "failed to get cost", http.StatusInternalServerError)
+		return
+	}
+
+	for _, c := range details {
+		if strings.EqualFold(trimmedKey(c.key), service) {
+			writeJSON(w, toCostView(c))
+			return
+		}
+	}
+
+	http.Error(w, fmt.Sprintf("service %q not found", service), http.StatusNotFound)
+}
+
+// slackRequestMaxAge rejects slash-command requests whose timestamp has
+// drifted too far from now, guarding against replayed requests.
+const slackRequestMaxAge = 5 * time.Minute
+
+// verifySlackSignature validates Slack's HMAC-SHA256 request signature:
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySlackSignature(r *http.Request, body []byte) bool {
+	if slackSigningSecret == "" {
+		return false
+	}
+
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(ts, 0)); age < 0 || age > slackRequestMaxAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(slackSigningSecret))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature")))
+}
+
+// handleSlackCommand backs /aws-cost slash commands, e.g. "/aws-cost today"
+// or "/aws-cost service EC2".
+func handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !verifySlackSignature(r, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	text, err := runSlackCommand(r.FormValue("text"))
+	if err != nil {
+		log.WithError(err).Error("failed to run slack command")
+		writeJSON(w, map[string]interface{}{"response_type": "ephemeral", "text": fmt.Sprintf("failed to get cost: %s", err)})
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"response_type": "in_channel", "text": text})
+}
+
+// parseDaysFlag parses an optional trailing "--days N" argument to
+// "service <NAME>", returning 0 when absent to mean "use the default
+// month-to-date/yesterday report".
+func parseDaysFlag(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	if len(args) != 2 || args[0] != "--days" {
+		return 0, errors.Errorf("unknown argument %q, expected --days N", strings.Join(args, " "))
+	}
+
+	days, err := strconv.Atoi(args[1])
+	if err != nil || days <= 0 {
+		return 0, errors.Errorf("invalid --days value %q, expected a positive integer", args[1])
+	}
+
+	return days, nil
+}
+
+// serviceCostOverDays reports a single service's total spend over the
+// trailing n days, backing "service <NAME> --days N".
+func serviceCostOverDays(costExplorer *costexplorer.CostExplorer, name string, days int) (string, error) {
+	now := time.Now().In(time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := today.AddDate(0, 0, -days)
+
+	amounts, err := queryCostAndUsage(costExplorer, start, today, "DAILY", "UnblendedCost", defaultGroupBy, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for key, amount := range amounts {
+		if strings.EqualFold(trimmedKey(key), name) {
+			return fmt.Sprintf("%s : %.3f %s over the last %d days", trimmedKey(key), amount.amount, strings.TrimSpace(amount.unit), days), nil
+		}
+	}
+
+	return "", errors.Errorf("service %q not found", name)
+}
+
+// runSlackCommand parses a slash-command's text ("today", "forecast",
+// "service <NAME> [--days N]") and returns a text reply. "today" (also used
+// for empty text) returns the same cost table the scheduled job posts;
+// "forecast" returns a one-line end-of-month forecast. "service" without
+// "--days" reports that service's month-to-date spend with its MoM/DoD
+// changes; with "--days N" it instead reports that service's total spend
+// over the trailing N days.
+func runSlackCommand(text string) (string, error) {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 {
+		fields = []string{"today"}
+	}
+
+	costExplorer, err := newCostExplorer()
+	if err != nil {
+		return "", err
+	}
+
+	switch fields[0] {
+	case "today":
+		details, monthForecast, err := getCosts(costExplorer)
+		if err != nil {
+			return "", err
+		}
+		return formatCostText(details, monthForecast), nil
+
+	case "forecast":
+		_, monthForecast, err := getCosts(costExplorer)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Forecast (end of month): %.3f %s", monthForecast.amount, strings.TrimSpace(monthForecast.unit)), nil
+
+	case "service":
+		if len(fields) < 2 {
+			return "", errors.New("usage: service <NAME> [--days N]")
+		}
+		name := fields[1]
+
+		days, err := parseDaysFlag(fields[2:])
+		if err != nil {
+			return "", err
+		}
+		if days > 0 {
+			return serviceCostOverDays(costExplorer, name, days)
+		}
+
+		details, _, err := getCosts(costExplorer)
+		if err != nil {
+			return "", err
+		}
+		for _, c := range details {
+			if strings.EqualFold(trimmedKey(c.key), name) {
+				return fmt.Sprintf(
+					"%s : %.3f %s (MoM %+.1f%%, DoD %+.1f%%)",
+					trimmedKey(c.key), c.monthToDate, strings.TrimSpace(c.unit),
+					percentChange(c.monthToDate, c.priorMonthToDate),
+					percentChange(c.yesterday, c.dayBeforeYesterday),
+				), nil
+			}
+		}
+		return "", errors.Errorf("service %q not found", name)
+
+	default:
+		return "", errors.Errorf("unknown command %q", fields[0])
+	}
+}
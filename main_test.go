@@ -0,0 +1,346 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/budgets"
+)
+
+func signSlackRequest(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSlackCommandRequest(timestamp string, signature string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/slack/command", nil)
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", signature)
+	return r
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	const secret = "test-signing-secret"
+	body := []byte("token=abc&command=%2Faws-cost&text=today")
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+
+	t.Run("valid signature is accepted", func(t *testing.T) {
+		slackSigningSecret = secret
+		defer func() { slackSigningSecret = "" }()
+
+		r := newSlackCommandRequest(timestamp, signSlackRequest(secret, timestamp, body))
+		if !verifySlackSignature(r, body) {
+			t.Fatal("expected a validly signed request to be accepted")
+		}
+	})
+
+	t.Run("tampered body is rejected", func(t *testing.T) {
+		slackSigningSecret = secret
+		defer func() { slackSigningSecret = "" }()
+
+		r := newSlackCommandRequest(timestamp, signSlackRequest(secret, timestamp, body))
+		tampered := []byte("token=abc&command=%2Faws-cost&text=forecast")
+		if verifySlackSignature(r, tampered) {
+			t.Fatal("expected a request with a tampered body to be rejected")
+		}
+	})
+
+	t.Run("tampered timestamp is rejected", func(t *testing.T) {
+		slackSigningSecret = secret
+		defer func() { slackSigningSecret = "" }()
+
+		r := newSlackCommandRequest(timestamp, signSlackRequest(secret, timestamp, body))
+		r.Header.Set("X-Slack-Request-Timestamp", fmt.Sprintf("%d", time.Now().Unix()-1))
+		if verifySlackSignature(r, body) {
+			t.Fatal("expected a request with a tampered timestamp to be rejected")
+		}
+	})
+
+	t.Run("stale timestamp is rejected", func(t *testing.T) {
+		slackSigningSecret = secret
+		defer func() { slackSigningSecret = "" }()
+
+		staleTimestamp := fmt.Sprintf("%d", time.Now().Add(-10*time.Minute).Unix())
+		r := newSlackCommandRequest(staleTimestamp, signSlackRequest(secret, staleTimestamp, body))
+		if verifySlackSignature(r, body) {
+			t.Fatal("expected a request with a stale timestamp to be rejected")
+		}
+	})
+
+	t.Run("missing signing secret is rejected", func(t *testing.T) {
+		slackSigningSecret = ""
+
+		r := newSlackCommandRequest(timestamp, signSlackRequest(secret, timestamp, body))
+		if verifySlackSignature(r, body) {
+			t.Fatal("expected verification to be rejected when ACS_SLACK_SIGNING_SECRET is unset")
+		}
+	})
+}
+
+func TestMeanStdDev(t *testing.T) {
+	mean, stdDev := meanStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if mean != 5 {
+		t.Fatalf("mean = %v, want 5", mean)
+	}
+	if stdDev != 2 {
+		t.Fatalf("stdDev = %v, want 2", stdDev)
+	}
+}
+
+func withAnomalyThresholds(t *testing.T, sigma float64, minUSD float64, maxJumpPercent float64) {
+	t.Helper()
+	origSigma, origMinUSD, origMaxJump := anomalySigma, anomalyMinUSD, anomalyMaxJumpPercent
+	anomalySigma, anomalyMinUSD, anomalyMaxJumpPercent = sigma, minUSD, maxJumpPercent
+	t.Cleanup(func() {
+		anomalySigma, anomalyMinUSD, anomalyMaxJumpPercent = origSigma, origMinUSD, origMaxJump
+	})
+}
+
+func flatSeries(n int, value float64) []float64 {
+	series := make([]float64, n)
+	for i := range series {
+		series[i] = value
+	}
+	return series
+}
+
+func TestEvaluateAnomaly(t *testing.T) {
+	t.Run("spend below anomalyMinUSD is not flagged", func(t *testing.T) {
+		withAnomalyThresholds(t, 3, 100, 0)
+		_, ok := evaluateAnomaly(cost{key: "Amazon EC2", yesterday: 50}, flatSeries(10, 50), "USD")
+		if ok {
+			t.Fatal("expected spend below anomalyMinUSD not to be flagged")
+		}
+	})
+
+	t.Run("too few baseline samples is not flagged", func(t *testing.T) {
+		withAnomalyThresholds(t, 3, 0, 0)
+		_, ok := evaluateAnomaly(cost{key: "Amazon EC2", yesterday: 1000}, flatSeries(anomalyMinSamples-1, 10), "USD")
+		if ok {
+			t.Fatal("expected too few baseline samples not to be flagged")
+		}
+	})
+
+	t.Run("spend within anomalySigma of baseline is not flagged", func(t *testing.T) {
+		withAnomalyThresholds(t, 3, 0, 0)
+		series := []float64{100, 102, 98, 101, 99, 100, 103, 97}
+		_, ok := evaluateAnomaly(cost{key: "Amazon EC2", yesterday: 105}, series, "USD")
+		if ok {
+			t.Fatal("expected spend within anomalySigma not to be flagged")
+		}
+	})
+
+	t.Run("spend beyond anomalySigma of baseline is flagged", func(t *testing.T) {
+		withAnomalyThresholds(t, 3, 0, 0)
+		series := []float64{100, 102, 98, 101, 99, 100, 103, 97}
+		a, ok := evaluateAnomaly(cost{key: "Amazon EC2", unit: "USD", yesterday: 1000}, series, "USD")
+		if !ok {
+			t.Fatal("expected a large spike above baseline to be flagged")
+		}
+		if a.key != "Amazon EC2" || a.amount != 1000 {
+			t.Fatalf("unexpected anomaly: %+v", a)
+		}
+	})
+
+	t.Run("flat zero baseline with a new spend spike is flagged", func(t *testing.T) {
+		withAnomalyThresholds(t, 3, 0, 50)
+		a, ok := evaluateAnomaly(cost{key: "New Service", unit: "USD", yesterday: 10000}, flatSeries(10, 0), "USD")
+		if !ok {
+			t.Fatal("expected a spend spike over a flat zero baseline to be flagged")
+		}
+		if a.baselineMean != 0 || a.baselineStdDev != 0 {
+			t.Fatalf("unexpected baseline stats: %+v", a)
+		}
+	})
+
+	t.Run("flat non-zero baseline with unchanged spend is not flagged", func(t *testing.T) {
+		withAnomalyThresholds(t, 3, 0, 50)
+		_, ok := evaluateAnomaly(cost{key: "Amazon EC2", yesterday: 500}, flatSeries(10, 500), "USD")
+		if ok {
+			t.Fatal("expected unchanged spend over a flat baseline not to be flagged")
+		}
+	})
+
+	t.Run("percent jump exceeding anomalyMaxJumpPercent is flagged even under sigma", func(t *testing.T) {
+		withAnomalyThresholds(t, 100, 0, 50)
+		series := []float64{100, 101, 99, 100, 101, 99, 100, 101}
+		_, ok := evaluateAnomaly(cost{key: "Amazon EC2", yesterday: 160}, series, "USD")
+		if !ok {
+			t.Fatal("expected a ~60% jump with anomalyMaxJumpPercent=50 to be flagged")
+		}
+	})
+}
+
+func TestParseDaysFlag(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{name: "no args defaults to 0", args: nil, want: 0},
+		{name: "--days N parses the count", args: []string{"--days", "7"}, want: 7},
+		{name: "unknown flag is rejected", args: []string{"--weeks", "1"}, wantErr: true},
+		{name: "non-integer value is rejected", args: []string{"--days", "soon"}, wantErr: true},
+		{name: "zero is rejected", args: []string{"--days", "0"}, wantErr: true},
+		{name: "negative is rejected", args: []string{"--days", "-1"}, wantErr: true},
+		{name: "extra trailing args are rejected", args: []string{"--days", "7", "extra"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseDaysFlag(c.args)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Fatalf("parseDaysFlag() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAggregateAccountCosts(t *testing.T) {
+	reports := []accountReport{
+		{
+			alias: "prod",
+			costs: []cost{
+				{key: "Total", unit: "*", monthToDate: 100},
+				{key: "Amazon EC2", unit: "USD", monthToDate: 100},
+			},
+			forecast: forecast{amount: 150, unit: "USD"},
+		},
+		{
+			alias: "staging",
+			costs: []cost{
+				{key: "Total", unit: "*", monthToDate: 20},
+			},
+			forecast: forecast{amount: 30, unit: "USD"},
+		},
+	}
+
+	total, fc := aggregateAccountCosts(reports)
+
+	if total.key != "All Accounts" {
+		t.Fatalf("total.key = %q, want %q", total.key, "All Accounts")
+	}
+	if total.monthToDate != 120 {
+		t.Fatalf("total.monthToDate = %v, want 120", total.monthToDate)
+	}
+	if fc.amount != 180 {
+		t.Fatalf("fc.amount = %v, want 180", fc.amount)
+	}
+	if fc.unit != "USD" {
+		t.Fatalf("fc.unit = %q, want %q (the account's synthetic Total row unit \"*\" must not clobber it)", fc.unit, "USD")
+	}
+}
+
+func TestMatchBudgetCost(t *testing.T) {
+	costByKey := map[string]cost{
+		"Amazon EC2": {key: "Amazon EC2", unit: "USD", monthToDate: 123.45},
+	}
+
+	cases := []struct {
+		name    string
+		budget  *budgets.Budget
+		wantKey string
+		wantOK  bool
+	}{
+		{
+			name: "service filter correlates with the matching cost detail",
+			budget: &budgets.Budget{
+				CostFilters: map[string][]*string{
+					"Service": {aws.String("Amazon EC2")},
+				},
+			},
+			wantKey: "Amazon EC2",
+			wantOK:  true,
+		},
+		{
+			name: "service filter with no matching cost detail does not correlate",
+			budget: &budgets.Budget{
+				CostFilters: map[string][]*string{
+					"Service": {aws.String("Amazon S3")},
+				},
+			},
+			wantOK: false,
+		},
+		{
+			name: "service filter with multiple values does not correlate",
+			budget: &budgets.Budget{
+				CostFilters: map[string][]*string{
+					"Service": {aws.String("Amazon EC2"), aws.String("Amazon S3")},
+				},
+			},
+			wantOK: false,
+		},
+		{
+			name: "tag filter does not correlate, since details is only grouped by service",
+			budget: &budgets.Budget{
+				CostFilters: map[string][]*string{
+					"TagKeyValue": {aws.String("user:Environment$production")},
+				},
+			},
+			wantOK: false,
+		},
+		{
+			name:   "nil CostFilters does not correlate",
+			budget: &budgets.Budget{},
+			wantOK: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := matchBudgetCost(c.budget, costByKey)
+			if ok != c.wantOK {
+				t.Fatalf("matchBudgetCost() ok = %v, want %v", ok, c.wantOK)
+			}
+			if ok && got.key != c.wantKey {
+				t.Fatalf("matchBudgetCost() key = %q, want %q", got.key, c.wantKey)
+			}
+		})
+	}
+}
+
+func TestEvaluateBudgetAlert(t *testing.T) {
+	cases := []struct {
+		name      string
+		limit     float64
+		actual    float64
+		forecast  float64
+		wantOK    bool
+		threshold float64
+	}{
+		{name: "zero limit never alerts", limit: 0, actual: 100, forecast: 100, wantOK: false},
+		{name: "below every threshold does not alert", limit: 1000, actual: 100, forecast: 200, wantOK: false},
+		{name: "actual spend crossing a threshold alerts", limit: 1000, actual: 850, forecast: 0, wantOK: true, threshold: 80},
+		{name: "forecast crossing a threshold alerts", limit: 1000, actual: 100, forecast: 1100, wantOK: true, threshold: 100},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			alert, ok := evaluateBudgetAlert("Amazon EC2", c.limit, c.actual, c.forecast, "USD")
+			if ok != c.wantOK {
+				t.Fatalf("evaluateBudgetAlert() ok = %v, want %v", ok, c.wantOK)
+			}
+			if ok && alert.threshold != c.threshold {
+				t.Fatalf("evaluateBudgetAlert() threshold = %v, want %v", alert.threshold, c.threshold)
+			}
+		})
+	}
+}